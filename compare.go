@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"os"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// runCompare implements the "compare" subcommand: it loads two runs saved
+// via --out, overlays their step1 median curves on one plot, and renders a
+// second plot of the per-size difference between them.
+func runCompare(args []string) {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	out := fs.String("out", "compare", "output file basename (without extension)")
+	fs.Parse(args)
+
+	dirs := fs.Args()
+	if len(dirs) != 2 {
+		fmt.Println("usage: estimate compare [--out name] <run-dir-a> <run-dir-b>")
+		os.Exit(1)
+	}
+
+	metaA, statsA, err := loadRun(dirs[0])
+	if err != nil {
+		fmt.Println("failed to load", dirs[0], ":", err)
+		os.Exit(1)
+	}
+	metaB, statsB, err := loadRun(dirs[1])
+	if err != nil {
+		fmt.Println("failed to load", dirs[1], ":", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("A: %-30s %s, GOMAXPROCS=%d, go %s\n", dirs[0], metaA.BrandName, metaA.GOMAXPROCS, metaA.GoVersion)
+	fmt.Printf("B: %-30s %s, GOMAXPROCS=%d, go %s\n", dirs[1], metaB.BrandName, metaB.GOMAXPROCS, metaB.GoVersion)
+
+	plotComparison(*out, dirs[0], statsA, dirs[1], statsB)
+}
+
+// step1Rows returns the step1 rows of a single probe: pointer-chase when
+// present, since that's what estimate_llc_size uses for knee-finding, and
+// random otherwise.
+func step1Rows(rows []StatRow) []StatRow {
+	probe := probeModeRandom
+	for _, r := range rows {
+		if r.Phase == "step1" && r.Probe == probeModePointerChase {
+			probe = probeModePointerChase
+			break
+		}
+	}
+
+	out := make([]StatRow, 0, len(rows))
+	for _, r := range rows {
+		if r.Phase == "step1" && r.Probe == probe {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// plotComparison overlays the step1 median curves of two runs on one plot
+// and renders their per-size difference on a second.
+func plotComparison(outBase, nameA string, statsA []StatRow, nameB string, statsB []StatRow) {
+	a := step1Rows(statsA)
+	b := step1Rows(statsB)
+
+	sizes := make([]int, len(a))
+	for i, r := range a {
+		sizes[i] = r.Size
+	}
+
+	palette := []color.Color{color.RGBA{R: 0xd6, G: 0x28, B: 0x28, A: 0xff}, color.RGBA{R: 0x28, G: 0x5f, B: 0xd6, A: 0xff}}
+
+	overlay := plot.New()
+	overlay.Title.Text = "Median access time comparison"
+	overlay.X.Label.Text = "Array Size"
+	overlay.Y.Label.Text = "Median Time"
+	overlay.X.Scale = plot.LogScale{}
+	overlay.X.Tick.Marker = SizeTicks{Sizes: sizes}
+	overlay.Y.Tick.Marker = DurationTicks{}
+
+	addLine := func(name string, rows []StatRow, col color.Color) {
+		xys := make(plotter.XYs, len(rows))
+		for i, r := range rows {
+			xys[i] = plotter.XY{X: float64(r.Size), Y: r.Stats.Median}
+		}
+		style := plotter.DefaultLineStyle
+		style.Color = col
+		overlay.Add(&plotter.Line{XYs: xys, LineStyle: style})
+		overlay.Legend.Add(name, &plotter.Line{XYs: xys, LineStyle: style})
+	}
+	addLine(nameA, a, palette[0])
+	addLine(nameB, b, palette[1])
+
+	if err := overlay.Save(30*vg.Centimeter, 20*vg.Centimeter, outBase+"_overlay.png"); err != nil {
+		panic(err)
+	}
+
+	bMedianBySize := make(map[int]float64, len(b))
+	for _, r := range b {
+		bMedianBySize[r.Size] = r.Stats.Median
+	}
+	diffXYs := make(plotter.XYs, 0, len(a))
+	for _, r := range a {
+		if medianB, ok := bMedianBySize[r.Size]; ok {
+			diffXYs = append(diffXYs, plotter.XY{X: float64(r.Size), Y: r.Stats.Median - medianB})
+		}
+	}
+
+	diff := plot.New()
+	diff.Title.Text = fmt.Sprintf("Difference (%s - %s)", nameA, nameB)
+	diff.X.Label.Text = "Array Size"
+	diff.Y.Label.Text = "Latency Delta"
+	diff.X.Scale = plot.LogScale{}
+	diff.X.Tick.Marker = SizeTicks{Sizes: sizes}
+	diff.Y.Tick.Marker = DurationTicks{}
+	diff.Add(&plotter.Line{XYs: diffXYs, LineStyle: plotter.DefaultLineStyle})
+
+	if err := diff.Save(30*vg.Centimeter, 20*vg.Centimeter, outBase+"_diff.png"); err != nil {
+		panic(err)
+	}
+}
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"image/color"
+	"math"
 	"math/rand"
+	"os"
+	"sort"
 	"strconv"
 	"time"
 
@@ -13,7 +18,41 @@ import (
 	"gonum.org/v1/plot/vg/draw"
 )
 
+// probeModeRandom indexes the array with a fresh random number each access,
+// as the original estimator did. probeModePointerChase instead walks a
+// random permutation embedded in the array itself, so the next address
+// depends only on the data just read - this removes the RNG/modulus cost
+// from the timing loop and defeats stride-based hardware prefetchers.
+const (
+	probeModeRandom       = "random"
+	probeModePointerChase = "pointer-chase"
+	probeModeBoth         = "both"
+)
+
+// trialsPerSize is how many independent timing runs are taken at each array
+// size, so the estimator sees a distribution rather than a single noisy
+// sample.
+const trialsPerSize = 9
+
+var probeMode = flag.String("probe", probeModeBoth, `probing strategy: "random", "pointer-chase", or "both"`)
+var outDir = flag.String("out", "", "directory to write raw measurements, aggregated stats, and run metadata to (optional)")
+
 func main() {
+	// "compare" is a subcommand rather than a flag, since it operates on
+	// saved runs instead of taking fresh measurements - dispatch on it
+	// before the normal flag set is parsed.
+	if len(os.Args) > 1 && os.Args[1] == "compare" {
+		runCompare(os.Args[2:])
+		return
+	}
+	flag.Parse()
+
+	switch *probeMode {
+	case probeModeRandom, probeModePointerChase, probeModeBoth:
+	default:
+		fmt.Printf("invalid -probe %q: must be %q, %q, or %q\n", *probeMode, probeModeRandom, probeModePointerChase, probeModeBoth)
+		os.Exit(1)
+	}
 
 	var CPU = cpuid.CPU
 	fmt.Println("CPU Information (similar to lscpu)")
@@ -22,93 +61,474 @@ func main() {
 	fmt.Println("L3 Cache:", formatBytes(int64(CPU.Cache.L3)))
 
 	fmt.Println("Running estimation algorithm")
-	estimate := estimate_llc_size()
-	fmt.Println("Estimated LLC size:", formatBytes(int64(estimate)))
+	estimate := estimate_llc_size(*probeMode, *outDir)
+
+	fmt.Println("\nDetected memory hierarchy:")
+	fmt.Printf("%-8s %-16s %-16s %s\n", "Level", "CPUID size", "Detected size", "Latency")
+	fmt.Printf("%-8s %-16s %-16s %s\n", "L1D", formatBytes(int64(CPU.Cache.L1D)), levelSize(estimate, "L1"), levelLatency(estimate, "L1"))
+	fmt.Printf("%-8s %-16s %-16s %s\n", "L2", formatBytes(int64(CPU.Cache.L2)), levelSize(estimate, "L2"), levelLatency(estimate, "L2"))
+	fmt.Printf("%-8s %-16s %-16s %s\n", "L3", formatBytes(int64(CPU.Cache.L3)), levelSize(estimate, "L3"), levelLatency(estimate, "L3"))
+	fmt.Printf("%-8s %-16s %-16s %s\n", "Memory", "-", levelSize(estimate, "Memory"), levelLatency(estimate, "Memory"))
+
+	fmt.Println("\nEstimated LLC size:", levelSize(estimate, "Memory"))
+}
+
+// CacheLevel describes one detected level of the memory hierarchy: the
+// array size at which this level's plateau ends (for Memory, the refined
+// LLC-to-memory boundary), and the mean of the per-size median access
+// latencies across that plateau.
+type CacheLevel struct {
+	Name    string
+	Size    int
+	Latency time.Duration
+}
+
+// CacheEstimate is the full set of levels detected by the piecewise-linear
+// segmentation, from L1 out to main memory.
+type CacheEstimate struct {
+	Levels []CacheLevel
+}
+
+// levelSize returns the formatted detected size for the named level, or "-"
+// if that level wasn't found.
+func levelSize(e CacheEstimate, name string) string {
+	for _, l := range e.Levels {
+		if l.Name == name {
+			return formatBytes(int64(l.Size))
+		}
+	}
+	return "-"
+}
+
+// levelLatency returns the formatted median latency for the named level, or
+// "-" if that level wasn't found.
+func levelLatency(e CacheEstimate, name string) string {
+	for _, l := range e.Levels {
+		if l.Name == name {
+			return l.Latency.String()
+		}
+	}
+	return "-"
+}
+
+// stats summarises a distribution of per-access latency samples (in
+// nanoseconds).
+type stats struct {
+	Min    float64 `json:"min_ns"`
+	Mean   float64 `json:"mean_ns"`
+	Median float64 `json:"median_ns"`
+	P95    float64 `json:"p95_ns"`
+	P99    float64 `json:"p99_ns"`
+	Max    float64 `json:"max_ns"`
+	StdDev float64 `json:"stddev_ns"`
+}
+
+// computeStats returns min/mean/median/p95/p99/max/stddev over samples.
+// samples is not mutated.
+func computeStats(samples []float64) stats {
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+
+	percentile := func(p float64) float64 {
+		idx := p * float64(n-1)
+		lo := int(math.Floor(idx))
+		hi := int(math.Ceil(idx))
+		if lo == hi {
+			return sorted[lo]
+		}
+		frac := idx - float64(lo)
+		return sorted[lo]*(1-frac) + sorted[hi]*frac
+	}
+
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	mean := sum / float64(n)
+
+	variance := 0.0
+	for _, v := range sorted {
+		d := v - mean
+		variance += d * d
+	}
+	variance /= float64(n)
+
+	return stats{
+		Min:    sorted[0],
+		Mean:   mean,
+		Median: percentile(0.5),
+		P95:    percentile(0.95),
+		P99:    percentile(0.99),
+		Max:    sorted[n-1],
+		StdDev: math.Sqrt(variance),
+	}
+}
+
+// SizeStats is the full set of per-trial samples and their summary stats
+// measured at one array size.
+type SizeStats struct {
+	Size    int
+	Samples []float64 // raw average-ns-per-access, one per trial
+	Stats   stats
+}
+
+// segment is a single linear piece of a piecewise-linear fit: the range of
+// sample indices it covers, its least-squares slope/intercept, and its
+// residual sum of squares.
+type segment struct {
+	start, end int
+	slope      float64
+	intercept  float64
+	ssr        float64
 }
 
-func estimate_llc_size() int {
+// linfit fits y = slope*x + intercept over xs[lo:hi+1], ys[lo:hi+1] by
+// ordinary least squares, returning the fit along with its residual sum of
+// squares.
+func linfit(xs, ys []float64, lo, hi int) (slope, intercept, ssr float64) {
+	n := float64(hi - lo + 1)
+	var sumX, sumY, sumXY, sumXX float64
+	for i := lo; i <= hi; i++ {
+		sumX += xs[i]
+		sumY += ys[i]
+		sumXY += xs[i] * ys[i]
+		sumXX += xs[i] * xs[i]
+	}
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		intercept = sumY / n
+	} else {
+		slope = (n*sumXY - sumX*sumY) / denom
+		intercept = (sumY - slope*sumX) / n
+	}
+	for i := lo; i <= hi; i++ {
+		d := ys[i] - (slope*xs[i] + intercept)
+		ssr += d * d
+	}
+	return
+}
+
+// fitBreakpoints splits xs/ys into nBreaks+1 linear segments by minimizing
+// the summed residual of all segments. It starts from evenly spaced
+// breakpoints and repeatedly nudges each one left or right, keeping any
+// move that reduces the total error, until no move helps - a local descent
+// over the breakpoint positions rather than a closed-form solution.
+func fitBreakpoints(xs, ys []float64, nBreaks int) []segment {
+	n := len(xs)
+	breaks := make([]int, nBreaks)
+	for i := range breaks {
+		breaks[i] = (i + 1) * n / (nBreaks + 1)
+	}
+
+	build := func(bps []int) []segment {
+		segs := make([]segment, 0, nBreaks+1)
+		lo := 0
+		for _, b := range bps {
+			slope, intercept, ssr := linfit(xs, ys, lo, b)
+			segs = append(segs, segment{start: lo, end: b, slope: slope, intercept: intercept, ssr: ssr})
+			lo = b + 1
+		}
+		slope, intercept, ssr := linfit(xs, ys, lo, n-1)
+		segs = append(segs, segment{start: lo, end: n - 1, slope: slope, intercept: intercept, ssr: ssr})
+		return segs
+	}
+
+	total := func(segs []segment) float64 {
+		sum := 0.0
+		for _, s := range segs {
+			sum += s.ssr
+		}
+		return sum
+	}
+
+	segs := build(breaks)
+	best := total(segs)
+	for improved := true; improved; {
+		improved = false
+		for i := range breaks {
+			lo, hi := 1, n-2
+			if i > 0 {
+				lo = breaks[i-1] + 1
+			}
+			if i < len(breaks)-1 {
+				hi = breaks[i+1] - 1
+			}
+			for _, step := range []int{-1, 1} {
+				candidate := breaks[i] + step
+				if candidate < lo || candidate > hi {
+					continue
+				}
+				trial := append([]int(nil), breaks...)
+				trial[i] = candidate
+				trialSegs := build(trial)
+				if ssr := total(trialSegs); ssr < best {
+					breaks[i], segs, best, improved = candidate, trialSegs, ssr, true
+				}
+			}
+		}
+	}
+	return segs
+}
+
+func estimate_llc_size(mode string, outDir string) CacheEstimate {
 	// Use a random source so we index the array randomly.
 	// This should help mess with the prefetching.
 	// It should also handle the issues with cachelines.
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 
+	// Accumulated across every testRange call so the full run - raw
+	// per-trial timings and their aggregated stats - can be persisted to
+	// disk once estimation finishes.
+	var allMeasurements []Measurement
+	var allStats []StatRow
+
 	// This type encompasses an entire cache line (assuming a cacheLineSize of 64 bytes).
 	// Using an array of these will force each access to be in a new cacheline.
+	// next holds the pointer-chase permutation: the index of the next node
+	// to visit, so a chase is just repeated "p = arr[p].next" with no RNG
+	// or modulus in the timing loop.
 	type cacheLine64 struct {
-		a int64 // 8 bytes
-		_ int64 // 16 bytes
-		_ int64 // 24 bytes
-		_ int64 // 32 bytes
-		_ int64 // 40 bytes
-		_ int64 // 48 bytes
-		_ int64 // 56 bytes
-		_ int64 // 64 bytes
-	}
-
-	// testRange tests the sizes given and records them in
-	// the results array. It accesses random values in the array and
-	// increments them so some operation is performed.
-	//
-	// The resulting array maps each size to the average execution time for each operation
-	testRange := func(sizes []int, iterations int) plotter.XYs {
-
-		xys := make(plotter.XYs, 0, len(sizes))
-		for _, size := range sizes {
-			arr := make([]cacheLine64, size/64)
-			arrLength := len(arr)
+		a    int64 // 8 bytes
+		next int64 // 16 bytes
+		_    int64 // 24 bytes
+		_    int64 // 32 bytes
+		_    int64 // 40 bytes
+		_    int64 // 48 bytes
+		_    int64 // 56 bytes
+		_    int64 // 64 bytes
+	}
+
+	// probeRandom is the original strategy: index the array with a fresh
+	// random number on every access.
+	probeRandom := func(arr []cacheLine64, iterations int) time.Duration {
+		arrLength := len(arr)
+		start := time.Now()
+		for i := 0; i < iterations; i++ {
+			arr[r.Int()%arrLength].a++
+		}
+		return time.Since(start)
+	}
+
+	// probePointerChase lays a random permutation of the array's indices
+	// into each node's next field, then walks the resulting cycle. Every
+	// access depends on the value just read, so the CPU can't prefetch
+	// ahead of the chase.
+	probePointerChase := func(arr []cacheLine64, iterations int) time.Duration {
+		arrLength := len(arr)
+		perm := r.Perm(arrLength)
+		for i, idx := range perm {
+			arr[idx].next = int64(perm[(i+1)%arrLength])
+		}
 
-			start := time.Now()
-			for i := 0; i < iterations; i++ {
-				arr[r.Int()%arrLength].a++
+		p := int64(0)
+		start := time.Now()
+		for i := 0; i < iterations; i++ {
+			p = arr[p].next
+		}
+		elapsed := time.Since(start)
+		arr[p].a++ // keep p live so the chase can't be optimised away
+		return elapsed
+	}
+
+	probes := map[string]func([]cacheLine64, int) time.Duration{
+		probeModeRandom:       probeRandom,
+		probeModePointerChase: probePointerChase,
+	}
+
+	// testRange runs trialsPerSize independent timings at each size using
+	// the named probing strategy, and returns the full per-trial samples
+	// alongside their summary statistics - a single noisy sample can't hide
+	// or fake a cache-level plateau the way an average can. phase labels
+	// which stage of the algorithm this call belongs to ("step1", "step2",
+	// ...) purely for persistence - it has no effect on the measurement.
+	testRange := func(phase, probeName string, sizes []int, iterations int) []SizeStats {
+		probe := probes[probeName]
+
+		results := make([]SizeStats, 0, len(sizes))
+		for _, size := range sizes {
+			samples := make([]float64, trialsPerSize)
+			for t := 0; t < trialsPerSize; t++ {
+				arr := make([]cacheLine64, size/64)
+				elapsed := probe(arr, iterations)
+				samples[t] = float64(elapsed.Nanoseconds()) / float64(iterations)
+				allMeasurements = append(allMeasurements, Measurement{
+					Phase: phase, Probe: probeName, Size: size, Trial: t, LatencyNs: samples[t],
+				})
 			}
-			elapsed := time.Since(start)
-			fmt.Printf("Array size: %-16s Duration: %s\n", formatBytes(int64(size)), elapsed)
+			st := computeStats(samples)
+			allStats = append(allStats, StatRow{Phase: phase, Probe: probeName, Size: size, Stats: st})
+			fmt.Printf("[%s] Array size: %-16s median: %-12s (min %s, max %s)\n",
+				probeName, formatBytes(int64(size)), time.Duration(st.Median), time.Duration(st.Min), time.Duration(st.Max))
 
-			xys = append(xys, plotter.XY{
-				X: float64(size),
-				Y: float64(int(elapsed.Nanoseconds()) / iterations), // Take the average
-			})
+			results = append(results, SizeStats{Size: size, Samples: samples, Stats: st})
 		}
-		return xys
+		return results
+	}
+
+	// probeResult pairs a probing strategy's per-size stats with its name,
+	// so multiple strategies can be overlaid on the same axes.
+	type probeResult struct {
+		name  string
+		stats []SizeStats
 	}
 
-	// plotXYs is a nice helper function which plots the output from testRange
-	// and saves it to a file. Helpful for debugging and seeing what the program is
-	// seeing!
-	plotXYs := func(filename string, xys plotter.XYs, sizes []int) {
+	// plotXYs plots the median of each series from testRange against array
+	// size and saves it to a file. Helpful for debugging and seeing what
+	// the program is seeing!
+	plotXYs := func(filename string, sizes []int, all ...probeResult) {
 		p := plot.New()
-		p.Title.Text = "Average access time for different sized arrays"
+		p.Title.Text = "Median access time for different sized arrays"
 		p.X.Label.Text = "Array Size"
-		p.Y.Label.Text = "Average Time"
+		p.Y.Label.Text = "Median Time"
 		p.Y.Tick.LineStyle = draw.LineStyle{}
 		p.X.Tick.Marker = SizeTicks{Sizes: sizes}
 		p.Y.Tick.Marker = DurationTicks{}
 		p.X.Scale = plot.LogScale{}
 
-		p.Add(&plotter.Line{
-			XYs:       xys,
-			LineStyle: plotter.DefaultLineStyle,
-		})
+		palette := []color.Color{color.RGBA{R: 0xd6, G: 0x28, B: 0x28, A: 0xff}, color.RGBA{R: 0x28, G: 0x5f, B: 0xd6, A: 0xff}}
+		for i, pr := range all {
+			xys := make(plotter.XYs, len(pr.stats))
+			for j, s := range pr.stats {
+				xys[j] = plotter.XY{X: float64(s.Size), Y: s.Stats.Median}
+			}
+			lineStyle := plotter.DefaultLineStyle
+			lineStyle.Color = palette[i%len(palette)]
+			p.Add(&plotter.Line{XYs: xys, LineStyle: lineStyle})
+			p.Legend.Add(pr.name, &plotter.Line{XYs: xys, LineStyle: lineStyle})
+		}
+
+		if err := p.Save(30*vg.Centimeter, 20*vg.Centimeter, filename+".png"); err != nil {
+			panic(err)
+		}
+	}
+
+	// plotBoxes renders one boxplot per size, showing the full spread of
+	// trial samples rather than hiding it behind a single point.
+	plotBoxes := func(filename string, sizeStats []SizeStats) {
+		p := plot.New()
+		p.Title.Text = "Per-size latency distribution"
+		p.Y.Label.Text = "Average Time"
+		p.Y.Tick.Marker = DurationTicks{}
+
+		names := make([]string, len(sizeStats))
+		for i, s := range sizeStats {
+			names[i] = formatBytes(int64(s.Size))
+			box, err := plotter.NewBoxPlot(vg.Points(20), float64(i), plotter.Values(s.Samples))
+			if err != nil {
+				panic(err)
+			}
+			p.Add(box)
+		}
+		p.NominalX(names...)
+
+		if err := p.Save(30*vg.Centimeter, 20*vg.Centimeter, filename+".png"); err != nil {
+			panic(err)
+		}
+	}
+
+	// plotTrace scatters every point visited during a search so the
+	// climb itself - not just its result - can be inspected for debugging.
+	plotTrace := func(filename string, title string, trace []SizeStats) {
+		p := plot.New()
+		p.Title.Text = title
+		p.X.Label.Text = "Array Size"
+		p.Y.Label.Text = "Median Time"
+		p.X.Scale = plot.LogScale{}
+		p.Y.Tick.Marker = DurationTicks{}
+
+		sizes := make([]int, len(trace))
+		xys := make(plotter.XYs, len(trace))
+		for i, s := range trace {
+			sizes[i] = s.Size
+			xys[i] = plotter.XY{X: float64(s.Size), Y: s.Stats.Median}
+		}
+		p.X.Tick.Marker = SizeTicks{Sizes: sizes}
+
+		scatter, err := plotter.NewScatter(xys)
+		if err != nil {
+			panic(err)
+		}
+		p.Add(scatter)
 
 		if err := p.Save(30*vg.Centimeter, 20*vg.Centimeter, filename+".png"); err != nil {
 			panic(err)
 		}
 	}
 
-	// Max slope doesn't actually return the max slope, it returns the index of the point
-	// which is steepest
-	maxSlope := func(xys plotter.XYs) int {
-		// Figure out where the largest step was, just using the time differences don't worry about the X value
-		max := 0.0
-		maxPos := 0
-		for i := 0; i < len(xys)-1; i++ {
-			slope := (xys[i+1].Y - xys[i].Y)
-			if slope > max {
-				max = slope
-				maxPos = i
+	// hillClimbBoundary refines a breakpoint region with stochastic hill
+	// climbing rather than a uniform linear sweep: the current estimate is
+	// the state, its neighbourhood is {state +/- delta, state +/- 2*delta},
+	// and each iteration moves to whichever neighbour has the steepest
+	// local slope (measured from a small cluster of samples around it).
+	// delta shrinks geometrically every round that fails to improve, and
+	// the search stops once delta is finer than resolution or improvement
+	// stalls for stallLimit rounds in a row - far fewer total measurements
+	// than sampling the whole region uniformly.
+	hillClimbBoundary := func(probeName string, lo, hi int) (int, []SizeStats) {
+		const resolution = 64 * 1024
+		const stallLimit = 3
+		const clusterHalfWidth = 2
+		const clusterStep = 16 * 1024
+
+		localSlope := func(center int) (float64, []SizeStats) {
+			clusterSizes := make([]int, 0, 2*clusterHalfWidth+1)
+			for i := -clusterHalfWidth; i <= clusterHalfWidth; i++ {
+				if size := center + i*clusterStep; size >= 64 {
+					clusterSizes = append(clusterSizes, size)
+				}
+			}
+			clusterStats := testRange("step2_shc", probeName, clusterSizes, 32_000_000)
+			if len(clusterStats) < 2 {
+				return 0, clusterStats
+			}
+			xs := make([]float64, len(clusterStats))
+			ys := make([]float64, len(clusterStats))
+			for i, s := range clusterStats {
+				xs[i] = float64(s.Size)
+				ys[i] = s.Stats.Median
+			}
+			slope, _, _ := linfit(xs, ys, 0, len(xs)-1)
+			return slope, clusterStats
+		}
+
+		state := (lo + hi) / 2
+		delta := (hi - lo) / 4
+		if delta < resolution {
+			delta = resolution
+		}
+
+		bestSlope, trace := localSlope(state)
+
+		for stalls := 0; delta >= resolution && stalls < stallLimit; {
+			// Evaluate every neighbour against this round's starting
+			// state, then move to the best of them - not whichever
+			// candidate happens to improve on bestSlope first.
+			roundState := state
+			bestCandidate := state
+			roundBestSlope := bestSlope
+			improved := false
+			for _, step := range []int{-2 * delta, -delta, delta, 2 * delta} {
+				candidate := roundState + step
+				if candidate < lo || candidate > hi {
+					continue
+				}
+				slope, clusterStats := localSlope(candidate)
+				trace = append(trace, clusterStats...)
+				if slope > roundBestSlope {
+					roundBestSlope, bestCandidate, improved = slope, candidate, true
+				}
+			}
+			if improved {
+				state, bestSlope = bestCandidate, roundBestSlope
+				stalls = 0
+			} else {
+				stalls++
+				delta /= 2
 			}
 		}
-		return maxPos
+
+		return state, trace
 	}
 
 	// Create sizes for the array
@@ -118,25 +538,84 @@ func estimate_llc_size() int {
 		sizes = append(sizes, sizes[len(sizes)-1]*2)
 	}
 
-	xys := testRange(sizes, 8_000_000)
-	plotXYs("step1", xys, sizes)
+	var probeNames []string
+	switch mode {
+	case probeModeBoth:
+		probeNames = []string{probeModeRandom, probeModePointerChase}
+	default:
+		probeNames = []string{mode}
+	}
+
+	step1 := make([]probeResult, len(probeNames))
+	for i, name := range probeNames {
+		step1[i] = probeResult{name: name, stats: testRange("step1", name, sizes, 8_000_000)}
+	}
+	plotXYs("step1", sizes, step1...)
+
+	// Prefer the pointer-chase curve for knee-finding when it's available:
+	// it shows the L1/L2/L3 transitions far more sharply since there's no
+	// prefetcher or RNG noise to smooth them out.
+	knee := step1[0]
+	for _, pr := range step1 {
+		if pr.name == probeModePointerChase {
+			knee = pr
+		}
+	}
+	plotBoxes("step1_boxplot", knee.stats)
+
+	// Fit a piecewise-linear curve to log(size) vs median latency: 3
+	// breakpoints gives 4 segments, one plateau per level of the hierarchy
+	// (L1, L2, L3, main memory).
+	xs := make([]float64, len(knee.stats))
+	ys := make([]float64, len(knee.stats))
+	for i, s := range knee.stats {
+		xs[i] = math.Log2(float64(s.Size))
+		ys[i] = s.Stats.Median
+	}
+	const levelCount = 3
+	segs := fitBreakpoints(xs, ys, levelCount)
+
+	levelNames := []string{"L1", "L2", "L3", "Memory"}
+	levels := make([]CacheLevel, len(segs))
+	for i, seg := range segs {
+		sum := 0.0
+		for j := seg.start; j <= seg.end; j++ {
+			sum += ys[j]
+		}
+		mean := sum / float64(seg.end-seg.start+1)
+		levels[i] = CacheLevel{
+			Name:    levelNames[i],
+			Latency: time.Duration(mean),
+		}
+	}
+	// Each level's detected size is where its plateau ends - the array
+	// size at which the *next* segment begins - since a segment's own
+	// start is actually the previous level's capacity boundary. The last
+	// level (Memory) is sized separately below, by hill-climbing.
+	for i := 0; i < len(segs)-1; i++ {
+		levels[i].Size = knee.stats[segs[i+1].start].Size
+	}
 
-	// Find the range with the largest difference, only regarding Y axis
-	maxPos := maxSlope(xys)
+	// Refine the LLC-to-memory transition (the last breakpoint) with
+	// stochastic hill climbing, since the log-scale segmentation only
+	// localises it to within a doubling of the array size.
+	llcSeg := segs[levelCount-1]
+	lo, hi := knee.stats[llcSeg.end].Size, knee.stats[llcSeg.end+1].Size
+	refinedSize, trace := hillClimbBoundary(knee.name, lo, hi)
+	plotTrace("step2_shc", "LLC boundary hill-climb trace", trace)
 
-	fmt.Printf("\nConverging on range %s-%s\n", formatBytes(int64(sizes[maxPos])), formatBytes(int64(sizes[maxPos+1])))
-	// Create a new sizes array, converging on that range but using a linear scale
-	sizeRange := sizes[maxPos+1] - sizes[maxPos]
-	const linearSteps = 8
-	stepSize := int(sizeRange / linearSteps)
-	convergedSizes := make([]int, linearSteps)
-	for i := 0; i < linearSteps; i++ {
-		convergedSizes[i] = sizes[maxPos] + i*stepSize
+	levels[levelCount].Size = refinedSize
+
+	if outDir != "" {
+		meta := collectMetadata(mode)
+		if err := saveRun(outDir, meta, allMeasurements, allStats); err != nil {
+			fmt.Println("warning: failed to save run to", outDir, ":", err)
+		} else {
+			fmt.Println("\nSaved raw measurements, stats, and metadata to", outDir)
+		}
 	}
-	xys = testRange(convergedSizes, 32_000_000)
-	plotXYs("step2", xys, convergedSizes)
 
-	return convergedSizes[maxSlope(xys)]
+	return CacheEstimate{Levels: levels}
 }
 
 // formats the bytes to IEC format
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"github.com/klauspost/cpuid/v2"
+)
+
+// Measurement is one raw per-trial timing, tagged with the phase of the
+// algorithm and probing strategy that produced it.
+type Measurement struct {
+	Phase     string  `json:"phase"`
+	Probe     string  `json:"probe"`
+	Size      int     `json:"size"`
+	Trial     int     `json:"trial"`
+	LatencyNs float64 `json:"latency_ns"`
+}
+
+// StatRow is the aggregated stats for one (phase, probe, size) combination.
+type StatRow struct {
+	Phase string `json:"phase"`
+	Probe string `json:"probe"`
+	Size  int    `json:"size"`
+	Stats stats  `json:"stats"`
+}
+
+// RunMetadata captures the environment a measurement run was taken in, so
+// runs can be meaningfully compared across machines or CPU-governor
+// settings later.
+type RunMetadata struct {
+	GoVersion     string `json:"go_version"`
+	GOMAXPROCS    int    `json:"gomaxprocs"`
+	BrandName     string `json:"brand_name"`
+	CacheLine     int    `json:"cache_line"`
+	L2            int    `json:"l2"`
+	L3            int    `json:"l3"`
+	ProbeMode     string `json:"probe_mode"`
+	TrialsPerSize int    `json:"trials_per_size"`
+}
+
+// collectMetadata gathers the run metadata for the current machine and
+// process.
+func collectMetadata(probeMode string) RunMetadata {
+	return RunMetadata{
+		GoVersion:     runtime.Version(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		BrandName:     cpuid.CPU.BrandName,
+		CacheLine:     cpuid.CPU.CacheLine,
+		L2:            cpuid.CPU.Cache.L2,
+		L3:            cpuid.CPU.Cache.L3,
+		ProbeMode:     probeMode,
+		TrialsPerSize: trialsPerSize,
+	}
+}
+
+// saveRun writes the raw measurements, aggregated stats, and metadata for a
+// run to dir, creating it if necessary. Each of measurements and stats is
+// written as both CSV (for quick inspection/spreadsheet use) and JSON (for
+// programmatic reuse, e.g. by the compare subcommand).
+func saveRun(dir string, meta RunMetadata, measurements []Measurement, statRows []StatRow) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	if err := saveMetadata(filepath.Join(dir, "metadata.json"), meta); err != nil {
+		return err
+	}
+	if err := saveMeasurements(filepath.Join(dir, "measurements.csv"), measurements); err != nil {
+		return err
+	}
+	if err := saveJSON(filepath.Join(dir, "measurements.json"), measurements); err != nil {
+		return err
+	}
+	if err := saveStats(filepath.Join(dir, "stats.csv"), statRows); err != nil {
+		return err
+	}
+	return saveJSON(filepath.Join(dir, "stats.json"), statRows)
+}
+
+// saveJSON writes v to path as indented JSON.
+func saveJSON(path string, v any) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func saveMetadata(path string, meta RunMetadata) error {
+	return saveJSON(path, meta)
+}
+
+func saveMeasurements(path string, rows []Measurement) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	defer w.Flush()
+
+	if err := w.Write([]string{"phase", "probe", "size", "trial", "latency_ns"}); err != nil {
+		return err
+	}
+	for _, m := range rows {
+		err := w.Write([]string{
+			m.Phase,
+			m.Probe,
+			strconv.Itoa(m.Size),
+			strconv.Itoa(m.Trial),
+			strconv.FormatFloat(m.LatencyNs, 'f', -1, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return w.Error()
+}
+
+func saveStats(path string, rows []StatRow) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	defer w.Flush()
+
+	header := []string{"phase", "probe", "size", "min_ns", "mean_ns", "median_ns", "p95_ns", "p99_ns", "max_ns", "stddev_ns"}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		err := w.Write([]string{
+			r.Phase,
+			r.Probe,
+			strconv.Itoa(r.Size),
+			strconv.FormatFloat(r.Stats.Min, 'f', -1, 64),
+			strconv.FormatFloat(r.Stats.Mean, 'f', -1, 64),
+			strconv.FormatFloat(r.Stats.Median, 'f', -1, 64),
+			strconv.FormatFloat(r.Stats.P95, 'f', -1, 64),
+			strconv.FormatFloat(r.Stats.P99, 'f', -1, 64),
+			strconv.FormatFloat(r.Stats.Max, 'f', -1, 64),
+			strconv.FormatFloat(r.Stats.StdDev, 'f', -1, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return w.Error()
+}
+
+// loadRun reads back a run previously written by saveRun.
+func loadRun(dir string) (RunMetadata, []StatRow, error) {
+	var meta RunMetadata
+	metaFile, err := os.Open(filepath.Join(dir, "metadata.json"))
+	if err != nil {
+		return meta, nil, err
+	}
+	defer metaFile.Close()
+	if err := json.NewDecoder(metaFile).Decode(&meta); err != nil {
+		return meta, nil, err
+	}
+
+	statsFile, err := os.Open(filepath.Join(dir, "stats.csv"))
+	if err != nil {
+		return meta, nil, err
+	}
+	defer statsFile.Close()
+
+	records, err := csv.NewReader(statsFile).ReadAll()
+	if err != nil {
+		return meta, nil, err
+	}
+	if len(records) == 0 {
+		return meta, nil, nil
+	}
+
+	parseFloat := func(s string) float64 {
+		v, _ := strconv.ParseFloat(s, 64)
+		return v
+	}
+
+	rows := make([]StatRow, 0, len(records)-1)
+	for _, rec := range records[1:] {
+		size, _ := strconv.Atoi(rec[2])
+		rows = append(rows, StatRow{
+			Phase: rec[0],
+			Probe: rec[1],
+			Size:  size,
+			Stats: stats{
+				Min:    parseFloat(rec[3]),
+				Mean:   parseFloat(rec[4]),
+				Median: parseFloat(rec[5]),
+				P95:    parseFloat(rec[6]),
+				P99:    parseFloat(rec[7]),
+				Max:    parseFloat(rec[8]),
+				StdDev: parseFloat(rec[9]),
+			},
+		})
+	}
+	return meta, rows, nil
+}